@@ -2,6 +2,7 @@ package zabbix
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type (
@@ -73,24 +75,45 @@ func (e *ExpectedMore) Error() string {
 
 // API use to store connection information
 type API struct {
-	Auth      string      // auth token, filled by Login()
-	Logger    *log.Logger // request/response logger, nil by default
-	UserAgent string
-	url       string
-	c         http.Client
-	id        int32
-	ex        sync.Mutex
-	Config    Config
+	Auth        string      // auth token, filled by Login()
+	Logger      *log.Logger // request/response logger, nil by default
+	UserAgent   string
+	url         string
+	c           http.Client
+	id          int32
+	sem         chan struct{}
+	authMu      sync.Mutex
+	user        string
+	rawVersion  string
+	tokenSource TokenSource
+	Config      Config
 }
 
 type Config struct {
-	Url         string
-	TlsNoVerify bool
-	Log         *log.Logger
-	Serialize   bool
-	Version     int
+	Url            string
+	TlsNoVerify    bool
+	Log            *log.Logger
+	Serialize      bool
+	Version        int
+	MaxBatchSize   int
+	DefaultTimeout time.Duration
+	Retry          RetryConfig
+	TokenCachePath string
+	TokenCacheTTL  time.Duration
+	TokenSource    TokenSource
+	ApiToken       string
+	TokenName      string
+	Middlewares    []func(next Caller) Caller
+	RequestHook    func(req *http.Request)
+	ResponseHook   func(res *http.Response)
 }
 
+// Caller performs a single logical JSON-RPC call and returns the raw
+// response body, as callBytes/doCall do. Config.Middlewares compose Callers
+// the way http.RoundTripper composition does, letting callers wrap metrics,
+// tracing, or recording around every call without reaching into callBytes.
+type Caller func(method string, params interface{}) ([]byte, error)
+
 func parseVersionString(vstr string) (version int64, err error) {
 	parts := strings.Split(vstr, ".")
 
@@ -133,6 +156,7 @@ func NewAPI(c Config) (api *API, err error) {
 		UserAgent: "github.com/tpretz/go-zabbix-api",
 		Logger:    c.Log,
 		Config:    c,
+		sem:       make(chan struct{}, 1),
 	}
 
 	if c.TlsNoVerify {
@@ -147,6 +171,20 @@ func NewAPI(c Config) (api *API, err error) {
 		api.printf("TLS running in insecure mode, do not use this configuration in production")
 	}
 
+	if c.TokenSource != nil {
+		api.tokenSource = c.TokenSource
+	} else if c.TokenCachePath != "" {
+		api.tokenSource = &FileTokenSource{Path: c.TokenCachePath}
+	}
+
+	if c.ApiToken != "" {
+		// pre-created (5.4+) API tokens bypass user.login entirely; still
+		// probe the version below to populate Config.Version as usual.
+		api.setAuth(c.ApiToken)
+	} else if api.loadCachedAuth() {
+		return
+	}
+
 	var rawVersion string
 	rawVersion, err = api.Version()
 	if err != nil {
@@ -173,76 +211,200 @@ func (api *API) printf(format string, v ...interface{}) {
 	}
 }
 
-func (api *API) callBytes(method string, params interface{}) (b []byte, err error) {
+// getAuth returns the current auth token under authMu, so a concurrent
+// RotateToken swapping it mid-flight can't race with a reader.
+func (api *API) getAuth() string {
+	api.authMu.Lock()
+	defer api.authMu.Unlock()
+	return api.Auth
+}
+
+// setAuth replaces the current auth token under authMu.
+func (api *API) setAuth(token string) {
+	api.authMu.Lock()
+	api.Auth = token
+	api.authMu.Unlock()
+}
+
+// buildRequest assembles a single JSON-RPC request object, allocating the
+// next atomic request id. The auth token is not put in the request object;
+// postBytes sends it as a Bearer Authorization header.
+func (api *API) buildRequest(method string, params interface{}) request {
 	id := atomic.AddInt32(&api.id, 1)
-	jsonobj := request{
-    Jsonrpc: "2.0",
-    Method:  method,
-    Params:  params,
-    ID:      id,
+	return request{
+		Jsonrpc: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      id,
 	}
-	
+}
 
-	b, err = json.Marshal(jsonobj)
-	if err != nil {
-		return
-	}
+// postBytes POSTs an already-marshaled JSON-RPC payload (a single request
+// object or a batch array) and returns the raw response body along with the
+// *http.Response (body already drained, but status/headers intact, e.g. for
+// a RetryClassifier to inspect Retry-After). ctx bounds both the wait for
+// the serialize semaphore and the HTTP round-trip.
+func (api *API) postBytes(ctx context.Context, b []byte) (res *http.Response, resp []byte, err error) {
 	api.printf("Request (POST): %s", b)
 
-	req, err := http.NewRequest("POST", api.url, bytes.NewReader(b))
+	req, err := http.NewRequestWithContext(ctx, "POST", api.url, bytes.NewReader(b))
 	if err != nil {
 		return
 	}
 	req.ContentLength = int64(len(b))
 	req.Header.Add("Content-Type", "application/json-rpc")
 	req.Header.Add("User-Agent", api.UserAgent)
-	if api.Auth != "" {
-    req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", api.Auth))
+	if auth := api.getAuth(); auth != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", auth))
+	}
+	if api.Config.RequestHook != nil {
+		api.Config.RequestHook(req)
 	}
-
 
 	if api.Config.Serialize {
-		api.ex.Lock()
-		defer api.ex.Unlock()
+		select {
+		case api.sem <- struct{}{}:
+			defer func() { <-api.sem }()
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		}
 	}
 
-	res, err := api.c.Do(req)
+	res, err = api.c.Do(req)
 	if err != nil {
 		api.printf("Error   : %s", err)
 		return
 	}
 	defer res.Body.Close()
+	if api.Config.ResponseHook != nil {
+		api.Config.ResponseHook(res)
+	}
 
-	b, err = ioutil.ReadAll(res.Body)
-	api.printf("Response (%d): %s", res.StatusCode, b)
+	resp, err = ioutil.ReadAll(res.Body)
+	api.printf("Response (%d): %s", res.StatusCode, resp)
 	return
 }
 
-// Call Calls specified API method. Uses api.Auth if not empty.
-// err is something network or marshaling related. Caller should inspect response.Error to get API error.
-func (api *API) Call(method string, params interface{}) (response Response, err error) {
-	b, err := api.callBytes(method, params)
+// withDefaultTimeout wraps ctx in a context.WithTimeout using
+// Config.DefaultTimeout when it is non-zero, otherwise returns ctx as-is.
+// The returned cancel func is always safe to defer.
+func (api *API) withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if api.Config.DefaultTimeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, api.Config.DefaultTimeout)
+}
+
+func (api *API) callBytesCtx(ctx context.Context, method string, params interface{}) (b []byte, err error) {
+	ctx, cancel := api.withDefaultTimeout(ctx)
+	defer cancel()
+
+	caller := api.buildCallerChain(func(method string, params interface{}) ([]byte, error) {
+		return api.doCall(ctx, method, params)
+	})
+	return caller(method, params)
+}
+
+// buildCallerChain wraps base in api.Config.Middlewares, outermost first, so
+// Middlewares[0] sees every call before Middlewares[1], and so on down to
+// base.
+func (api *API) buildCallerChain(base Caller) Caller {
+	c := base
+	for i := len(api.Config.Middlewares) - 1; i >= 0; i-- {
+		c = api.Config.Middlewares[i](c)
+	}
+	return c
+}
+
+// doCall performs the retrying JSON-RPC request/response cycle for a single
+// logical call (method, params), with no middleware involved.
+func (api *API) doCall(ctx context.Context, method string, params interface{}) (b []byte, err error) {
+	_, b, err = api.retryLoop(ctx, func() (*http.Response, []byte, error) {
+		// a fresh request (and JSON-RPC id) is built on every attempt so log
+		// correlation stays meaningful across retries.
+		jsonobj := api.buildRequest(method, params)
+
+		reqBytes, merr := json.Marshal(jsonobj)
+		if merr != nil {
+			return nil, nil, merr
+		}
+		return api.postBytes(ctx, reqBytes)
+	})
+	return
+}
+
+// retryLoop repeatedly invokes attempt (which should build and POST a fresh
+// payload each call, so JSON-RPC ids stay meaningful) according to
+// Config.Retry, stopping when the result is no longer retryable, the
+// attempt budget is spent, or ctx is canceled. It is shared by doCall (a
+// single JSON-RPC request) and CallBatchCtx (a batch array), so both get
+// the same backoff/jitter policy.
+func (api *API) retryLoop(ctx context.Context, attempt func() (*http.Response, []byte, error)) (res *http.Response, b []byte, err error) {
+	maxAttempts := api.Config.Retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	classify := api.Config.Retry.Classifier
+	if classify == nil {
+		classify = DefaultRetryClassifier
+	}
+
+	for i := 0; ; i++ {
+		res, b, err = attempt()
+		if i+1 >= maxAttempts || !classify(res, err) {
+			return
+		}
+
+		delay := api.Config.Retry.delay(i, res)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return res, b, ctx.Err()
+		}
+	}
+}
+
+func (api *API) callBytes(method string, params interface{}) (b []byte, err error) {
+	return api.callBytesCtx(context.Background(), method, params)
+}
+
+// CallCtx is the context-aware equivalent of Call: ctx bounds the request
+// and, combined with Config.Serialize, lets a canceled caller give up
+// without waiting behind a stuck in-flight request.
+func (api *API) CallCtx(ctx context.Context, method string, params interface{}) (response Response, err error) {
+	b, err := api.callBytesCtx(ctx, method, params)
 	if err == nil {
 		err = json.Unmarshal(b, &response)
 	}
 	return
 }
 
-// CallWithError Uses Call() and then sets err to response.Error if former is nil and latter is not.
-func (api *API) CallWithError(method string, params interface{}) (response Response, err error) {
-	response, err = api.Call(method, params)
+// Call Calls specified API method. Uses api.Auth if not empty.
+// err is something network or marshaling related. Caller should inspect response.Error to get API error.
+func (api *API) Call(method string, params interface{}) (response Response, err error) {
+	return api.CallCtx(context.Background(), method, params)
+}
+
+// CallWithErrorCtx is the context-aware equivalent of CallWithError.
+func (api *API) CallWithErrorCtx(ctx context.Context, method string, params interface{}) (response Response, err error) {
+	response, err = api.CallCtx(ctx, method, params)
 	if err == nil && response.Error != nil {
 		err = response.Error
 	}
 	return
 }
 
-// CallWithErrorParse Calls specified API method.
-// Parse the response of the api in the result variable.
-func (api *API) CallWithErrorParse(method string, params interface{}, result interface{}) (err error) {
+// CallWithError Uses Call() and then sets err to response.Error if former is nil and latter is not.
+func (api *API) CallWithError(method string, params interface{}) (response Response, err error) {
+	return api.CallWithErrorCtx(context.Background(), method, params)
+}
+
+// CallWithErrorParseCtx is the context-aware equivalent of CallWithErrorParse.
+func (api *API) CallWithErrorParseCtx(ctx context.Context, method string, params interface{}, result interface{}) (err error) {
 	var rawResult RawResponse
 
-	response, err := api.callBytes(method, params)
+	response, err := api.callBytesCtx(ctx, method, params)
 	if err != nil {
 		return
 	}
@@ -257,42 +419,63 @@ func (api *API) CallWithErrorParse(method string, params interface{}, result int
 	return
 }
 
-// Login Calls "user.login" API method and fills api.Auth field.
+// CallWithErrorParse Calls specified API method.
+// Parse the response of the api in the result variable.
+func (api *API) CallWithErrorParse(method string, params interface{}, result interface{}) (err error) {
+	return api.CallWithErrorParseCtx(context.Background(), method, params, result)
+}
+
+// LoginCtx is the context-aware equivalent of Login.
 // This method modifies API structure and should not be called concurrently with other methods.
-func (api *API) Login(user, password string) (auth string, err error) {
+func (api *API) LoginCtx(ctx context.Context, user, password string) (auth string, err error) {
 	var response Response
 	if api.Config.Version >= 50400 {
-		response, err = api.CallWithError("user.login", map[string]string{"username": user, "password": password})
+		response, err = api.CallWithErrorCtx(ctx, "user.login", map[string]string{"username": user, "password": password})
 	} else {
-		response, err = api.CallWithError("user.login", map[string]string{"user": user, "password": password})
+		response, err = api.CallWithErrorCtx(ctx, "user.login", map[string]string{"user": user, "password": password})
 	}
 	if err != nil {
 		return
 	}
 
 	auth = response.Result.(string)
-	api.Auth = auth
+	api.setAuth(auth)
+	api.user = user
+	api.storeCachedAuth(user)
 	return
 }
 
-// Version Calls "APIInfo.version" API method.
+// Login Calls "user.login" API method and fills api.Auth field.
+// This method modifies API structure and should not be called concurrently with other methods.
+func (api *API) Login(user, password string) (auth string, err error) {
+	return api.LoginCtx(context.Background(), user, password)
+}
+
+// VersionCtx is the context-aware equivalent of Version.
 // This method temporary modifies API structure and should not be called concurrently with other methods.
-func (api *API) Version() (v string, err error) {
+func (api *API) VersionCtx(ctx context.Context) (v string, err error) {
 	// temporary remove auth for this method to succeed
 	// https://www.zabbix.com/documentation/2.2/manual/appendix/api/apiinfo/version
-	auth := api.Auth
-	api.Auth = ""
-	response, err := api.CallWithError("APIInfo.version", Params{})
-	api.Auth = auth
+	auth := api.getAuth()
+	api.setAuth("")
+	response, err := api.CallWithErrorCtx(ctx, "APIInfo.version", Params{})
+	api.setAuth(auth)
 
 	// despite what documentation says, Zabbix 2.2 requires auth, so we try again
 	if e, ok := err.(*Error); ok && e.Code == -32602 {
-		response, err = api.CallWithError("APIInfo.version", Params{})
+		response, err = api.CallWithErrorCtx(ctx, "APIInfo.version", Params{})
 	}
 	if err != nil {
 		return
 	}
 
 	v = response.Result.(string)
+	api.rawVersion = v
 	return
 }
+
+// Version Calls "APIInfo.version" API method.
+// This method temporary modifies API structure and should not be called concurrently with other methods.
+func (api *API) Version() (v string, err error) {
+	return api.VersionCtx(context.Background())
+}
@@ -0,0 +1,61 @@
+package zabbix
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestPostBytesCtxCancelWhileWaitingForSerializeSemaphore exercises
+// Config.Serialize: a caller blocked waiting for the single serialize slot
+// must give up as soon as ctx is canceled, rather than waiting for the slot
+// to free up, and must never reach the server.
+func TestPostBytesCtxCancelWhileWaitingForSerializeSemaphore(t *testing.T) {
+	var gotRequest bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRequest = true
+	}))
+	defer srv.Close()
+
+	api := &API{url: srv.URL, c: http.Client{}, sem: make(chan struct{}, 1)}
+	api.Config.Serialize = true
+
+	// hold the only serialize slot for the duration of the test.
+	api.sem <- struct{}{}
+	defer func() { <-api.sem }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, _, err := api.postBytes(ctx, []byte(`{}`))
+	if err != context.DeadlineExceeded {
+		t.Fatalf("postBytes() err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if gotRequest {
+		t.Fatal("postBytes() reached the server while still waiting for the serialize semaphore")
+	}
+}
+
+// TestPostBytesSerializeAllowsSequentialRequests verifies Config.Serialize
+// doesn't deadlock the common case: once the slot is free, postBytes
+// acquires and releases it around a single request.
+func TestPostBytesSerializeAllowsSequentialRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	api := &API{url: srv.URL, c: http.Client{}, sem: make(chan struct{}, 1)}
+	api.Config.Serialize = true
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := api.postBytes(context.Background(), []byte(`{}`)); err != nil {
+			t.Fatalf("postBytes() call %d: %v", i, err)
+		}
+	}
+	if len(api.sem) != 0 {
+		t.Fatalf("serialize semaphore left holding %d slots, want 0", len(api.sem))
+	}
+}
@@ -0,0 +1,166 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// BatchCall describes a single call to be included in a JSON-RPC batch
+// request via CallBatch / CallBatchWithErrorParse.
+type BatchCall struct {
+	Method string
+	Params interface{}
+}
+
+// defaultMaxBatchSize is used when Config.MaxBatchSize is not set (<= 0).
+const defaultMaxBatchSize = 100
+
+func (api *API) maxBatchSize() int {
+	if api.Config.MaxBatchSize > 0 {
+		return api.Config.MaxBatchSize
+	}
+	return defaultMaxBatchSize
+}
+
+// CallBatchCtx is the context-aware equivalent of CallBatch. Each chunk (see
+// Config.MaxBatchSize) goes through the same Config.Retry backoff policy as
+// a single call, and the whole call is bounded by Config.DefaultTimeout.
+func (api *API) CallBatchCtx(ctx context.Context, calls []BatchCall) (responses []Response, err error) {
+	ctx, cancel := api.withDefaultTimeout(ctx)
+	defer cancel()
+
+	responses = make([]Response, len(calls))
+
+	for start := 0; start < len(calls); start += api.maxBatchSize() {
+		end := start + api.maxBatchSize()
+		if end > len(calls) {
+			end = len(calls)
+		}
+
+		chunk := calls[start:end]
+		// idToIndex gains a fresh entry per call on every attempt inside
+		// retryLoop (ids are regenerated per attempt; earlier attempts' ids
+		// stay in the map, pointing at the same index), so it's captured by
+		// reference here and read back once the final attempt settles.
+		idToIndex := make(map[int32]int, len(chunk))
+
+		// Routed through the same Config.Middlewares chain as a single call,
+		// as one call per chunk labeled "batch" (params is the chunk of
+		// BatchCalls), so metrics/tracing/recording middleware see batches
+		// as well as single calls.
+		caller := api.buildCallerChain(func(_ string, _ interface{}) ([]byte, error) {
+			_, resp, perr := api.retryLoop(ctx, func() (*http.Response, []byte, error) {
+				requests := make([]request, len(chunk))
+				for i, call := range chunk {
+					requests[i] = api.buildRequest(call.Method, call.Params)
+					idToIndex[requests[i].ID] = start + i
+				}
+
+				b, merr := json.Marshal(requests)
+				if merr != nil {
+					return nil, nil, merr
+				}
+				return api.postBytes(ctx, b)
+			})
+			return resp, perr
+		})
+
+		resp, perr := caller("batch", chunk)
+		if perr != nil {
+			err = perr
+			return
+		}
+
+		var chunkResponses []Response
+		if err = json.Unmarshal(resp, &chunkResponses); err != nil {
+			return
+		}
+
+		for _, r := range chunkResponses {
+			idx, ok := idToIndex[r.ID]
+			if !ok {
+				err = fmt.Errorf("zabbix: batch response id %d does not match any request", r.ID)
+				return
+			}
+			responses[idx] = r
+		}
+	}
+
+	return
+}
+
+// CallBatch sends calls as a single JSON-RPC batch request (or several,
+// transparently split according to Config.MaxBatchSize), and returns the
+// responses matched back to calls by position, regardless of the order the
+// server returned them in.
+func (api *API) CallBatch(calls []BatchCall) (responses []Response, err error) {
+	return api.CallBatchCtx(context.Background(), calls)
+}
+
+// CallBatchWithErrorParseCtx is the context-aware equivalent of
+// CallBatchWithErrorParse.
+func (api *API) CallBatchWithErrorParseCtx(ctx context.Context, calls []BatchCall, results []interface{}) (err error) {
+	if len(results) != len(calls) {
+		return fmt.Errorf("zabbix: CallBatchWithErrorParse expects %d results, got %d", len(calls), len(results))
+	}
+
+	responses, err := api.CallBatchCtx(ctx, calls)
+	if err != nil {
+		return
+	}
+
+	for i, response := range responses {
+		if response.Error != nil {
+			return response.Error
+		}
+		b, merr := json.Marshal(response.Result)
+		if merr != nil {
+			return merr
+		}
+		if err = json.Unmarshal(b, results[i]); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// CallBatchWithErrorParse is the batch equivalent of CallWithErrorParse: it
+// sends calls as one or more JSON-RPC batches and unmarshals each call's
+// result into the caller-supplied value at the same index in results. len(results)
+// must equal len(calls).
+func (api *API) CallBatchWithErrorParse(calls []BatchCall, results []interface{}) (err error) {
+	return api.CallBatchWithErrorParseCtx(context.Background(), calls, results)
+}
+
+// Batcher accumulates BatchCall entries for later submission via Send,
+// letting callers build up a pipelined batch fluently:
+//
+//	results, err := api.Batcher().
+//		Add("host.get", Params{}).
+//		Add("item.get", Params{}).
+//		Send()
+type Batcher struct {
+	api   *API
+	calls []BatchCall
+}
+
+// Batcher returns a new Batcher bound to api.
+func (api *API) Batcher() *Batcher {
+	return &Batcher{api: api}
+}
+
+// Add queues a call for the next Send, returning the Batcher for chaining.
+func (b *Batcher) Add(method string, params interface{}) *Batcher {
+	b.calls = append(b.calls, BatchCall{Method: method, Params: params})
+	return b
+}
+
+// Send submits all queued calls via API.CallBatch and clears the queue.
+func (b *Batcher) Send() (responses []Response, err error) {
+	responses, err = b.api.CallBatch(b.calls)
+	b.calls = nil
+	return
+}
@@ -0,0 +1,101 @@
+package zabbix
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestAPI(url string) *API {
+	return &API{url: url, c: http.Client{}, sem: make(chan struct{}, 1)}
+}
+
+// TestCallBatchDemuxesOutOfOrderResponses exercises the case the batch
+// request explicitly calls out: JSON-RPC does not guarantee response order,
+// so CallBatch must match responses back to calls by id, not by position in
+// the server's array.
+func TestCallBatchDemuxesOutOfOrderResponses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []request
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			t.Fatalf("decode batch request: %v", err)
+		}
+
+		// respond in reverse order to prove the client doesn't assume
+		// positional correspondence.
+		responses := make([]Response, len(reqs))
+		for i, req := range reqs {
+			responses[len(reqs)-1-i] = Response{Jsonrpc: "2.0", ID: req.ID, Result: req.Method}
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer srv.Close()
+
+	api := newTestAPI(srv.URL)
+
+	calls := []BatchCall{
+		{Method: "host.get", Params: Params{}},
+		{Method: "item.get", Params: Params{}},
+		{Method: "trigger.get", Params: Params{}},
+	}
+
+	responses, err := api.CallBatch(calls)
+	if err != nil {
+		t.Fatalf("CallBatch: %v", err)
+	}
+	if len(responses) != len(calls) {
+		t.Fatalf("got %d responses, want %d", len(responses), len(calls))
+	}
+	for i, call := range calls {
+		if responses[i].Result != call.Method {
+			t.Errorf("index %d: want result %q, got %v", i, call.Method, responses[i].Result)
+		}
+	}
+}
+
+// TestCallBatchSplitsOversizedBatches verifies Config.MaxBatchSize
+// transparently splits a large call list into several batch requests while
+// keeping the combined result correctly ordered.
+func TestCallBatchSplitsOversizedBatches(t *testing.T) {
+	var gotBatches [][]request
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []request
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			t.Fatalf("decode batch request: %v", err)
+		}
+		gotBatches = append(gotBatches, reqs)
+
+		responses := make([]Response, len(reqs))
+		for i, req := range reqs {
+			responses[i] = Response{Jsonrpc: "2.0", ID: req.ID, Result: req.Method}
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer srv.Close()
+
+	api := newTestAPI(srv.URL)
+	api.Config.MaxBatchSize = 2
+
+	calls := []BatchCall{
+		{Method: "a.get", Params: Params{}},
+		{Method: "b.get", Params: Params{}},
+		{Method: "c.get", Params: Params{}},
+		{Method: "d.get", Params: Params{}},
+		{Method: "e.get", Params: Params{}},
+	}
+
+	responses, err := api.CallBatch(calls)
+	if err != nil {
+		t.Fatalf("CallBatch: %v", err)
+	}
+	if len(gotBatches) != 3 {
+		t.Fatalf("expected 3 chunked requests for 5 calls at MaxBatchSize=2, got %d", len(gotBatches))
+	}
+	for i, call := range calls {
+		if responses[i].Result != call.Method {
+			t.Errorf("index %d: want result %q, got %v", i, call.Method, responses[i].Result)
+		}
+	}
+}
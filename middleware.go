@@ -0,0 +1,162 @@
+package zabbix
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// MethodStats holds the running counters for a single JSON-RPC method, as
+// collected by MetricsMiddleware.
+type MethodStats struct {
+	Count        int64
+	Errors       int64
+	TotalLatency time.Duration
+}
+
+// Metrics is a minimal, dependency-free stand-in for a Prometheus counter/
+// histogram pair, keyed by JSON-RPC method. Callers that already run a
+// Prometheus registry can walk Snapshot() and feed it into their own
+// collector instead of depending on client_golang from this module.
+type Metrics struct {
+	mu    sync.Mutex
+	stats map[string]*MethodStats
+}
+
+// NewMetrics returns an empty Metrics collector.
+func NewMetrics() *Metrics {
+	return &Metrics{stats: make(map[string]*MethodStats)}
+}
+
+func (m *Metrics) record(method string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.stats[method]
+	if !ok {
+		s = &MethodStats{}
+		m.stats[method] = s
+	}
+	s.Count++
+	s.TotalLatency += latency
+	if err != nil {
+		s.Errors++
+	}
+}
+
+// Snapshot returns a copy of the per-method stats collected so far.
+func (m *Metrics) Snapshot() map[string]MethodStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make(map[string]MethodStats, len(m.stats))
+	for method, s := range m.stats {
+		out[method] = *s
+	}
+	return out
+}
+
+// MetricsMiddleware returns a Config.Middlewares entry that records a
+// count/error/latency sample into m for every call, keyed by method.
+func MetricsMiddleware(m *Metrics) func(Caller) Caller {
+	return func(next Caller) Caller {
+		return func(method string, params interface{}) ([]byte, error) {
+			start := time.Now()
+			b, err := next(method, params)
+			m.record(method, time.Since(start), err)
+			return b, err
+		}
+	}
+}
+
+// Span is the minimal span interface TracingMiddleware needs: set a couple
+// of attributes describing the call, then end it. It is intentionally
+// smaller than go.opentelemetry.io/otel/trace.Span so this module doesn't
+// have to depend on the OpenTelemetry SDK; adapt it with a couple of lines
+// on the caller's side.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	End()
+}
+
+// Tracer starts a Span for a named operation. An OpenTelemetry tracer can be
+// adapted to this interface with a one-line wrapper around
+// tracer.Start(ctx, name).
+type Tracer interface {
+	Start(name string) Span
+}
+
+// TracingMiddleware returns a Config.Middlewares entry that opens a Span
+// named after the JSON-RPC method for every call, and records the method
+// name and the Zabbix error code (if any) as attributes before ending it.
+func TracingMiddleware(tracer Tracer) func(Caller) Caller {
+	return func(next Caller) Caller {
+		return func(method string, params interface{}) ([]byte, error) {
+			span := tracer.Start(method)
+			defer span.End()
+			span.SetAttribute("zabbix.method", method)
+
+			b, err := next(method, params)
+
+			if err != nil {
+				span.SetAttribute("zabbix.error", err.Error())
+			} else if zerr := responseError(b); zerr != nil {
+				span.SetAttribute("zabbix.error_code", zerr.Code)
+			}
+			return b, err
+		}
+	}
+}
+
+// responseError best-effort parses a raw JSON-RPC response to pull out its
+// error, for tracing/recording middleware; it returns nil on anything that
+// doesn't parse as a single Response (e.g. a batch array).
+func responseError(b []byte) *Error {
+	var response Response
+	if json.Unmarshal(b, &response) != nil {
+		return nil
+	}
+	return response.Error
+}
+
+// recordedCall is one NDJSON line written by RecordingMiddleware.
+type recordedCall struct {
+	Method     string      `json:"method"`
+	Params     interface{} `json:"params"`
+	Response   string      `json:"response,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	DurationMs int64       `json:"duration_ms"`
+}
+
+// RecordingMiddleware returns a Config.Middlewares entry that writes one
+// NDJSON line per call to w, for later offline replay in tests. Writes to w
+// are serialized, since w itself may not be safe for concurrent use.
+func RecordingMiddleware(w io.Writer) func(Caller) Caller {
+	var mu sync.Mutex
+
+	return func(next Caller) Caller {
+		return func(method string, params interface{}) ([]byte, error) {
+			start := time.Now()
+			b, err := next(method, params)
+
+			rec := recordedCall{
+				Method:     method,
+				Params:     params,
+				Response:   string(b),
+				DurationMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				rec.Error = err.Error()
+			}
+
+			if line, merr := json.Marshal(rec); merr == nil {
+				mu.Lock()
+				w.Write(append(line, '\n'))
+				mu.Unlock()
+			}
+
+			return b, err
+		}
+	}
+}
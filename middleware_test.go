@@ -0,0 +1,92 @@
+package zabbix
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// orderingMiddleware records name into calls both before and after invoking
+// next, so the recorded sequence reveals composition order.
+func orderingMiddleware(name string, calls *[]string) func(Caller) Caller {
+	return func(next Caller) Caller {
+		return func(method string, params interface{}) ([]byte, error) {
+			*calls = append(*calls, name+":before")
+			b, err := next(method, params)
+			*calls = append(*calls, name+":after")
+			return b, err
+		}
+	}
+}
+
+// TestBuildCallerChainOrdersMiddlewaresOutermostFirst verifies
+// Config.Middlewares[0] sees every call before Middlewares[1], wrapping
+// outermost-to-innermost the way http.RoundTripper composition does.
+func TestBuildCallerChainOrdersMiddlewaresOutermostFirst(t *testing.T) {
+	var calls []string
+	api := &API{}
+	api.Config.Middlewares = []func(Caller) Caller{
+		orderingMiddleware("outer", &calls),
+		orderingMiddleware("inner", &calls),
+	}
+
+	base := func(method string, params interface{}) ([]byte, error) {
+		calls = append(calls, "base")
+		return []byte(`{}`), nil
+	}
+
+	chain := api.buildCallerChain(base)
+	if _, err := chain("host.get", Params{}); err != nil {
+		t.Fatalf("chain(): %v", err)
+	}
+
+	want := []string{"outer:before", "inner:before", "base", "inner:after", "outer:after"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", calls, want)
+		}
+	}
+}
+
+// TestCallBatchRoutesThroughMiddlewares verifies CallBatchCtx sends each
+// chunk through Config.Middlewares (as a single "batch" call), the same as a
+// single CallCtx, rather than bypassing the chain.
+func TestCallBatchRoutesThroughMiddlewares(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []request
+		json.NewDecoder(r.Body).Decode(&reqs)
+		responses := make([]Response, len(reqs))
+		for i, req := range reqs {
+			responses[i] = Response{Jsonrpc: "2.0", ID: req.ID, Result: req.Method}
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer srv.Close()
+
+	var methods []string
+	api := &API{url: srv.URL, c: http.Client{}, sem: make(chan struct{}, 1)}
+	api.Config.Middlewares = []func(Caller) Caller{
+		func(next Caller) Caller {
+			return func(method string, params interface{}) ([]byte, error) {
+				methods = append(methods, method)
+				return next(method, params)
+			}
+		},
+	}
+
+	calls := []BatchCall{
+		{Method: "host.get", Params: Params{}},
+		{Method: "item.get", Params: Params{}},
+	}
+	if _, err := api.CallBatch(calls); err != nil {
+		t.Fatalf("CallBatch: %v", err)
+	}
+
+	if len(methods) != 1 || methods[0] != "batch" {
+		t.Fatalf("middleware saw methods %v, want a single \"batch\" call", methods)
+	}
+}
@@ -0,0 +1,128 @@
+package zabbix
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryClassifier decides whether a call that returned res (possibly nil, on
+// a transport error) and err should be retried.
+type RetryClassifier func(res *http.Response, err error) bool
+
+// RetryConfig configures the automatic retry layer used by callBytesCtx.
+// A zero value disables retries (MaxAttempts defaults to 1 call, no retry).
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 1 are treated as 1 (no retry).
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the computed backoff delay.
+	MaxDelay time.Duration
+	// Multiplier scales BaseDelay for each subsequent attempt. Defaults to 2
+	// when left at zero.
+	Multiplier float64
+	// JitterFraction applies +/-JitterFraction*delay uniform jitter, e.g.
+	// 0.1 for +/-10%.
+	JitterFraction float64
+	// Classifier decides whether to retry a given response/error. Defaults
+	// to DefaultRetryClassifier when nil.
+	Classifier RetryClassifier
+}
+
+// DefaultRetryClassifier retries connection errors, EOF, and HTTP
+// 429/502/503/504 responses. Permanent failures (TLS/cert errors, DNS
+// NXDOMAIN, malformed requests, ...) are not retried.
+func DefaultRetryClassifier(res *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return true
+		}
+		// http.Client errors are wrapped in *url.Error; unwrap to classify
+		// the underlying net error rather than treating every error alike.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		// net.Error covers *net.OpError and *net.DNSError (both report
+		// Timeout()); fall back to inspecting the syscall errno for the
+		// connection-refused/-reset cases net.Error doesn't flag as timeouts.
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return netErr.Timeout() || isConnRefusedOrReset(err)
+		}
+		return isConnRefusedOrReset(err)
+	}
+	if res == nil {
+		return false
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isConnRefusedOrReset reports whether err (or something it wraps) is a
+// connection-refused or connection-reset syscall error, the common case for
+// a transient dialing/keep-alive failure that isn't surfaced as net.Error.
+func isConnRefusedOrReset(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// delay computes the backoff for a given zero-based attempt index, honoring
+// a Retry-After header on res when present.
+func (c RetryConfig) delay(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if d, ok := retryAfterDelay(res); ok {
+			return d
+		}
+	}
+
+	mult := c.Multiplier
+	if mult <= 0 {
+		mult = 2
+	}
+
+	d := float64(c.BaseDelay) * math.Pow(mult, float64(attempt))
+	if c.MaxDelay > 0 && d > float64(c.MaxDelay) {
+		d = float64(c.MaxDelay)
+	}
+
+	if c.JitterFraction > 0 {
+		jitter := d * c.JitterFraction
+		d += (rand.Float64()*2 - 1) * jitter
+	}
+	if d < 0 {
+		d = 0
+	}
+	return time.Duration(d)
+}
+
+// retryAfterDelay parses a Retry-After response header, supporting both the
+// delay-seconds and HTTP-date forms.
+func retryAfterDelay(res *http.Response) (time.Duration, bool) {
+	h := res.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(h); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
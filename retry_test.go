@@ -0,0 +1,91 @@
+package zabbix
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// timeoutErr is a minimal net.Error that reports itself as a timeout,
+// standing in for the kind of error http.Client surfaces on a dial/read
+// timeout without depending on a real timed-out connection.
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestDefaultRetryClassifier(t *testing.T) {
+	cases := []struct {
+		name string
+		res  *http.Response
+		err  error
+		want bool
+	}{
+		{"no response no error", nil, nil, false},
+		{"429 too many requests", &http.Response{StatusCode: http.StatusTooManyRequests}, nil, true},
+		{"502 bad gateway", &http.Response{StatusCode: http.StatusBadGateway}, nil, true},
+		{"503 unavailable", &http.Response{StatusCode: http.StatusServiceUnavailable}, nil, true},
+		{"504 gateway timeout", &http.Response{StatusCode: http.StatusGatewayTimeout}, nil, true},
+		{"200 ok", &http.Response{StatusCode: http.StatusOK}, nil, false},
+		{"400 bad request", &http.Response{StatusCode: http.StatusBadRequest}, nil, false},
+		{"connection refused", nil, &url.Error{Op: "Post", URL: "http://x", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, true},
+		{"dial timeout", nil, &url.Error{Op: "Post", URL: "http://x", Err: &net.OpError{Op: "dial", Err: timeoutErr{}}}, true},
+		{"dns not found", nil, &net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		{"context canceled", nil, context.Canceled, false},
+		{"deadline exceeded", nil, context.DeadlineExceeded, false},
+		{"tls certificate error", nil, errors.New("x509: certificate signed by unknown authority"), false},
+		{"malformed url", nil, errors.New("parse \":bad\": missing protocol scheme"), false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := DefaultRetryClassifier(c.res, c.err); got != c.want {
+				t.Errorf("DefaultRetryClassifier() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRetryConfigDelayBackoff(t *testing.T) {
+	cfg := RetryConfig{BaseDelay: 100 * time.Millisecond, Multiplier: 2, MaxDelay: time.Second}
+
+	if got := cfg.delay(0, nil); got != 100*time.Millisecond {
+		t.Errorf("attempt 0: got %v, want 100ms", got)
+	}
+	if got := cfg.delay(1, nil); got != 200*time.Millisecond {
+		t.Errorf("attempt 1: got %v, want 200ms", got)
+	}
+	if got := cfg.delay(2, nil); got != 400*time.Millisecond {
+		t.Errorf("attempt 2: got %v, want 400ms", got)
+	}
+	// attempt 5 would be 100ms * 2^5 = 3.2s, capped to MaxDelay.
+	if got := cfg.delay(5, nil); got != time.Second {
+		t.Errorf("attempt 5: got %v, want capped %v", got, time.Second)
+	}
+}
+
+func TestRetryConfigDelayJitterStaysInBounds(t *testing.T) {
+	cfg := RetryConfig{BaseDelay: 100 * time.Millisecond, Multiplier: 1, JitterFraction: 0.5}
+
+	for i := 0; i < 100; i++ {
+		d := cfg.delay(0, nil)
+		if d < 50*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatalf("delay %v outside +/-50%% jitter bounds of 100ms", d)
+		}
+	}
+}
+
+func TestRetryConfigDelayHonorsRetryAfterSeconds(t *testing.T) {
+	cfg := RetryConfig{BaseDelay: time.Second, Multiplier: 2}
+	res := &http.Response{Header: http.Header{"Retry-After": []string{"5"}}}
+
+	if got := cfg.delay(3, res); got != 5*time.Second {
+		t.Errorf("got %v, want 5s from Retry-After header", got)
+	}
+}
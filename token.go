@@ -0,0 +1,85 @@
+package zabbix
+
+import (
+	"context"
+	"fmt"
+)
+
+// apiToken is the token.get / token.generate row shape we care about.
+type apiToken struct {
+	TokenID string `json:"tokenid"`
+	Token   string `json:"token"`
+}
+
+// authenticatedUser is the subset of user.checkAuthentication's result we
+// need to resolve the current session's user id.
+type authenticatedUser struct {
+	UserID string `json:"userid"`
+}
+
+// currentUserID resolves the userid behind the currently-authenticated
+// session/token via "user.checkAuthentication", since token.get itself has
+// no "self" sentinel and requires a real userid.
+func (api *API) currentUserID(ctx context.Context) (string, error) {
+	var user authenticatedUser
+	err := api.CallWithErrorParseCtx(ctx, "user.checkAuthentication", Params{"token": api.getAuth()}, &user)
+	if err != nil {
+		return "", err
+	}
+	if user.UserID == "" {
+		return "", fmt.Errorf("zabbix: user.checkAuthentication returned no userid for the current token")
+	}
+	return user.UserID, nil
+}
+
+// RotateToken rotates the currently-authenticated long-lived API token
+// (Config.ApiToken, or one obtained via Login): it resolves the current
+// user id, looks up their token via "token.get", calls "token.generate" to
+// mint a replacement, and swaps api.Auth to the new value. In-flight
+// requests that already read the old token finish against it; only
+// subsequent calls use the new one.
+//
+// A user may hold more than one long-lived token (e.g. one per
+// integration), so "token.get" is filtered down to Config.TokenName when
+// set. Without a TokenName, RotateToken refuses to guess: it errors out
+// rather than rotating an arbitrary token if the lookup returns more than
+// one.
+func (api *API) RotateToken(ctx context.Context) (err error) {
+	userID, err := api.currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := Params{"userids": []string{userID}}
+	if api.Config.TokenName != "" {
+		params["filter"] = Params{"name": []string{api.Config.TokenName}}
+	}
+
+	var tokens []apiToken
+	err = api.CallWithErrorParseCtx(ctx, "token.get", params, &tokens)
+	if err != nil {
+		return
+	}
+	if len(tokens) == 0 {
+		if api.Config.TokenName != "" {
+			return fmt.Errorf("zabbix: no API token named %q found for user %s", api.Config.TokenName, userID)
+		}
+		return fmt.Errorf("zabbix: no API token found for user %s", userID)
+	}
+	if len(tokens) > 1 {
+		return fmt.Errorf("zabbix: user %s has %d API tokens, set Config.TokenName to disambiguate which one to rotate", userID, len(tokens))
+	}
+
+	var generated []apiToken
+	err = api.CallWithErrorParseCtx(ctx, "token.generate", []string{tokens[0].TokenID}, &generated)
+	if err != nil {
+		return
+	}
+	if len(generated) == 0 {
+		return fmt.Errorf("zabbix: token.generate returned no token for tokenid %s", tokens[0].TokenID)
+	}
+
+	api.setAuth(generated[0].Token)
+	api.storeCachedAuth(api.user)
+	return
+}
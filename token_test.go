@@ -0,0 +1,116 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// rotateTokenServer fakes the three calls RotateToken makes in sequence
+// (user.checkAuthentication, token.get, token.generate), returning
+// tokenIDsForUser for the token.get lookup and newToken for token.generate.
+func rotateTokenServer(t *testing.T, tokenIDsForUser []string, newToken string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+
+		var result interface{}
+		switch req.Method {
+		case "user.checkAuthentication":
+			result = authenticatedUser{UserID: "7"}
+		case "token.get":
+			tokens := make([]apiToken, len(tokenIDsForUser))
+			for i, id := range tokenIDsForUser {
+				tokens[i] = apiToken{TokenID: id}
+			}
+			result = tokens
+		case "token.generate":
+			result = []apiToken{{TokenID: "1", Token: newToken}}
+		default:
+			t.Fatalf("unexpected method %q", req.Method)
+		}
+
+		json.NewEncoder(w).Encode(Response{Jsonrpc: "2.0", ID: req.ID, Result: result})
+	}))
+}
+
+func TestRotateTokenSwapsAuthAtomically(t *testing.T) {
+	srv := rotateTokenServer(t, []string{"1"}, "new-token")
+	defer srv.Close()
+
+	api := &API{url: srv.URL, c: http.Client{}, sem: make(chan struct{}, 1)}
+	api.setAuth("old-token")
+
+	if err := api.RotateToken(context.Background()); err != nil {
+		t.Fatalf("RotateToken: %v", err)
+	}
+	if got := api.getAuth(); got != "new-token" {
+		t.Fatalf("api.Auth = %q after RotateToken, want %q", got, "new-token")
+	}
+}
+
+// TestRotateTokenRefusesAmbiguousTokensWithoutTokenName covers the
+// multi-token case: with no Config.TokenName to disambiguate, RotateToken
+// must error out rather than rotating an arbitrary one of the user's
+// tokens.
+func TestRotateTokenRefusesAmbiguousTokensWithoutTokenName(t *testing.T) {
+	srv := rotateTokenServer(t, []string{"1", "2"}, "new-token")
+	defer srv.Close()
+
+	api := &API{url: srv.URL, c: http.Client{}, sem: make(chan struct{}, 1)}
+	api.setAuth("old-token")
+
+	err := api.RotateToken(context.Background())
+	if err == nil {
+		t.Fatal("RotateToken() = nil, want an error when the user has multiple tokens")
+	}
+	if !strings.Contains(err.Error(), "TokenName") {
+		t.Fatalf("RotateToken() error = %q, want it to mention Config.TokenName", err.Error())
+	}
+	if got := api.getAuth(); got != "old-token" {
+		t.Fatalf("api.Auth = %q after a failed RotateToken, want it left unchanged at %q", got, "old-token")
+	}
+}
+
+// TestRotateTokenWithTokenNameFiltersLookup verifies Config.TokenName is
+// sent as a token.get filter, so a user with several tokens can still
+// rotate the one they mean.
+func TestRotateTokenWithTokenNameFiltersLookup(t *testing.T) {
+	var gotFilter interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var result interface{}
+		switch req.Method {
+		case "user.checkAuthentication":
+			result = authenticatedUser{UserID: "7"}
+		case "token.get":
+			if p, ok := req.Params.(map[string]interface{}); ok {
+				gotFilter = p["filter"]
+			}
+			result = []apiToken{{TokenID: "2"}}
+		case "token.generate":
+			result = []apiToken{{TokenID: "2", Token: "new-token"}}
+		}
+		json.NewEncoder(w).Encode(Response{Jsonrpc: "2.0", ID: req.ID, Result: result})
+	}))
+	defer srv.Close()
+
+	api := &API{url: srv.URL, c: http.Client{}, sem: make(chan struct{}, 1)}
+	api.setAuth("old-token")
+	api.Config.TokenName = "ci-integration"
+
+	if err := api.RotateToken(context.Background()); err != nil {
+		t.Fatalf("RotateToken: %v", err)
+	}
+	if gotFilter == nil {
+		t.Fatal("token.get was not sent a filter despite Config.TokenName being set")
+	}
+}
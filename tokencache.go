@@ -0,0 +1,174 @@
+package zabbix
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CachedToken is the persisted shape of a cached session: enough to skip
+// user.login (and, if still fresh, the APIInfo.version probe) on the next
+// run against the same Zabbix instance.
+type CachedToken struct {
+	Url           string    `json:"url"`
+	Username      string    `json:"username"`
+	Token         string    `json:"token"`
+	ZabbixVersion string    `json:"zabbix_version"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// TokenSource loads and stores a CachedToken, letting callers plug in
+// alternative backends (a keyring, Vault, ...) in place of the default
+// FileTokenSource without touching the core API type.
+type TokenSource interface {
+	// LoadToken returns the cached token, or (nil, nil) if none is stored.
+	LoadToken() (*CachedToken, error)
+	// StoreToken persists token, replacing any previously stored value.
+	StoreToken(token *CachedToken) error
+	// InvalidateToken discards any persisted token.
+	InvalidateToken() error
+}
+
+// defaultTokenCacheTTL is used when Config.TokenCacheTTL is not set (<= 0).
+const defaultTokenCacheTTL = 12 * time.Hour
+
+// FileTokenSource is the default TokenSource, storing a single CachedToken
+// as a JSON file at Path with 0600 permissions, written atomically via a
+// temp file + rename in the same directory.
+type FileTokenSource struct {
+	Path string
+}
+
+// LoadToken reads and parses the cached token file. It refuses to load (and
+// returns an error rather than a token) if the file's permissions are
+// looser than 0600, since the file contains a live auth token.
+func (f *FileTokenSource) LoadToken() (*CachedToken, error) {
+	info, err := os.Stat(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("zabbix: token cache %s has overly permissive mode %04o, refusing to load", f.Path, info.Mode().Perm())
+	}
+
+	b, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var token CachedToken
+	if err = json.Unmarshal(b, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// StoreToken writes token to Path atomically: it marshals to a temp file in
+// the same directory (so the rename is on the same filesystem), chmods it
+// to 0600, then renames it over Path.
+func (f *FileTokenSource) StoreToken(token *CachedToken) error {
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.Path)
+	tmp, err := os.CreateTemp(dir, ".zabbix-token-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once the rename below succeeds
+
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmpPath, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, f.Path)
+}
+
+// InvalidateToken removes the cache file, if any.
+func (f *FileTokenSource) InvalidateToken() error {
+	err := os.Remove(f.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// InvalidateTokenCache discards the cached token (if Config.TokenCachePath
+// or Config.TokenSource is set) and clears api.Auth, so later calls go out
+// without a token until Login is called again and a later NewAPI does not
+// reuse the discarded token. Callers should call this after an auth error such as
+// a -32602 "not authorized" response.
+func (api *API) InvalidateTokenCache() error {
+	api.setAuth("")
+	if api.tokenSource == nil {
+		return nil
+	}
+	return api.tokenSource.InvalidateToken()
+}
+
+// loadCachedAuth attempts to populate api.Auth (and api.Config.Version) from
+// api.tokenSource, returning true if it found a fresh, matching entry.
+func (api *API) loadCachedAuth() bool {
+	if api.tokenSource == nil {
+		return false
+	}
+
+	cached, err := api.tokenSource.LoadToken()
+	if err != nil {
+		api.printf("token cache: %s", err)
+		return false
+	}
+	if cached == nil || cached.Url != api.url || !time.Now().Before(cached.ExpiresAt) {
+		return false
+	}
+
+	version, err := parseVersionString(cached.ZabbixVersion)
+	if err != nil {
+		api.printf("token cache: %s", err)
+		return false
+	}
+
+	api.setAuth(cached.Token)
+	api.user = cached.Username
+	api.rawVersion = cached.ZabbixVersion
+	api.Config.Version = int(version)
+	return true
+}
+
+// storeCachedAuth persists the current session, if api.tokenSource is set.
+func (api *API) storeCachedAuth(user string) {
+	if api.tokenSource == nil {
+		return
+	}
+
+	ttl := api.Config.TokenCacheTTL
+	if ttl <= 0 {
+		ttl = defaultTokenCacheTTL
+	}
+
+	err := api.tokenSource.StoreToken(&CachedToken{
+		Url:           api.url,
+		Username:      user,
+		Token:         api.getAuth(),
+		ZabbixVersion: api.rawVersion,
+		ExpiresAt:     time.Now().Add(ttl),
+	})
+	if err != nil {
+		api.printf("token cache: failed to store token: %s", err)
+	}
+}
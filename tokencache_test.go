@@ -0,0 +1,120 @@
+package zabbix
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileTokenSourceStoreLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	f := &FileTokenSource{Path: filepath.Join(dir, "token.json")}
+
+	want := &CachedToken{
+		Url:           "http://zabbix.example/api_jsonrpc.php",
+		Username:      "admin",
+		Token:         "s3cr3t",
+		ZabbixVersion: "6.0.0",
+		ExpiresAt:     time.Now().Add(time.Hour).UTC(),
+	}
+	if err := f.StoreToken(want); err != nil {
+		t.Fatalf("StoreToken: %v", err)
+	}
+
+	got, err := f.LoadToken()
+	if err != nil {
+		t.Fatalf("LoadToken: %v", err)
+	}
+	if got == nil || got.Url != want.Url || got.Username != want.Username || got.Token != want.Token {
+		t.Fatalf("LoadToken() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFileTokenSourceStoreWrites0600(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.json")
+	f := &FileTokenSource{Path: path}
+
+	if err := f.StoreToken(&CachedToken{Token: "x"}); err != nil {
+		t.Fatalf("StoreToken: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("token cache mode = %04o, want 0600", perm)
+	}
+}
+
+// TestFileTokenSourceLoadRefusesOverlyPermissiveMode covers the permission
+// check in LoadToken: a cache file the rest of the group/world can read must
+// be refused rather than silently trusted, since it carries a live auth
+// token.
+func TestFileTokenSourceLoadRefusesOverlyPermissiveMode(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.json")
+	if err := os.WriteFile(path, []byte(`{"token":"x"}`), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	f := &FileTokenSource{Path: path}
+	token, err := f.LoadToken()
+	if err == nil {
+		t.Fatalf("LoadToken() = %+v, nil, want an error for mode 0644", token)
+	}
+	if token != nil {
+		t.Fatalf("LoadToken() returned a token alongside an error: %+v", token)
+	}
+}
+
+func TestFileTokenSourceLoadMissingFileReturnsNil(t *testing.T) {
+	f := &FileTokenSource{Path: filepath.Join(t.TempDir(), "missing.json")}
+
+	token, err := f.LoadToken()
+	if err != nil {
+		t.Fatalf("LoadToken: %v", err)
+	}
+	if token != nil {
+		t.Fatalf("LoadToken() = %+v, want nil for a missing file", token)
+	}
+}
+
+func TestFileTokenSourceStoreLeavesNoTempFileBehind(t *testing.T) {
+	dir := t.TempDir()
+	f := &FileTokenSource{Path: filepath.Join(dir, "token.json")}
+
+	if err := f.StoreToken(&CachedToken{Token: "x"}); err != nil {
+		t.Fatalf("StoreToken: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "token.json" {
+		t.Fatalf("dir contains %v, want only token.json (no leftover temp file)", entries)
+	}
+}
+
+func TestFileTokenSourceInvalidateToken(t *testing.T) {
+	dir := t.TempDir()
+	f := &FileTokenSource{Path: filepath.Join(dir, "token.json")}
+
+	if err := f.StoreToken(&CachedToken{Token: "x"}); err != nil {
+		t.Fatalf("StoreToken: %v", err)
+	}
+	if err := f.InvalidateToken(); err != nil {
+		t.Fatalf("InvalidateToken: %v", err)
+	}
+	if _, err := os.Stat(f.Path); !os.IsNotExist(err) {
+		t.Fatalf("token cache file still present after InvalidateToken, stat err = %v", err)
+	}
+
+	// invalidating an already-absent cache is a no-op, not an error.
+	if err := f.InvalidateToken(); err != nil {
+		t.Fatalf("InvalidateToken on missing file: %v", err)
+	}
+}